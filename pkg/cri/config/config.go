@@ -0,0 +1,68 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package config holds the configuration of the CRI plugin.
+package config
+
+// Config contains CRI plugin configuration.
+type Config struct {
+	// ContainerdConfig contains config related to containerd
+	ContainerdConfig `toml:"containerd" json:"containerd"`
+}
+
+// ContainerdConfig contains configuration related to the containerd's
+// default settings used in the CRI plugin.
+type ContainerdConfig struct {
+	// Runtimes is a map from CRI RuntimeHandler strings, which specify types
+	// of runtime configurations, to the matching configurations.
+	Runtimes map[string]Runtime `toml:"runtimes" json:"runtimes"`
+	// DefaultRuntimeName is the default runtime name to use.
+	DefaultRuntimeName string `toml:"default_runtime_name" json:"defaultRuntimeName"`
+	// IgnoreRdtNotEnabledErrors ignores RDT related errors if RDT is not
+	// enabled instead of failing container creation.
+	IgnoreRdtNotEnabledErrors bool `toml:"ignore_rdt_not_enabled_errors" json:"ignoreRdtNotEnabledErrors"`
+	// IgnoreBlockIONotEnabledErrors ignores Block IO related errors if
+	// Block IO is not enabled instead of failing container creation.
+	IgnoreBlockIONotEnabledErrors bool `toml:"ignore_blockio_not_enabled_errors" json:"ignoreBlockIONotEnabledErrors"`
+	// CDIClassMapping declares the CDI-backed class/QoS resource classes
+	// available on this node, mapping each class name to the CDI qualified
+	// device names (e.g. "vendor.com/nic=eth0") it resolves to.
+	CDIClassMapping map[string][]string `toml:"cdi_class_mapping" json:"cdiClassMapping"`
+	// RdtConfigFile is the RDT class/QoS resource config file watched for
+	// changes, reloaded on SIGHUP or modification without requiring a
+	// containerd restart. Left empty, RDT class definitions are not
+	// watched for changes by the CRI plugin.
+	RdtConfigFile string `toml:"rdt_config_file" json:"rdtConfigFile"`
+	// BlockioConfigFile is the Block IO class/QoS resource config file
+	// watched for changes, reloaded on SIGHUP or modification without
+	// requiring a containerd restart. Left empty, Block IO class
+	// definitions are not watched for changes by the CRI plugin.
+	BlockioConfigFile string `toml:"blockio_config_file" json:"blockioConfigFile"`
+}
+
+// Runtime contains low-level configuration for a containerd runtime
+// handler, e.g. for runc or a VM based shim like kata-containers.
+type Runtime struct {
+	// Type is the runtime type to use in containerd, e.g. io.containerd.runc.v2
+	Type string `toml:"runtime_type" json:"runtimeType"`
+	// Options are config options for the runtime.
+	Options map[string]interface{} `toml:"options" json:"options"`
+	// CgroupDriver overrides the cgroup driver reported for this runtime
+	// handler by the CRI RuntimeConfig RPC. Accepts "systemd" or
+	// "cgroupfs"; left empty, the driver is instead inferred from the
+	// runtime handler's own shim options (e.g. runc's SystemdCgroup flag).
+	CgroupDriver string `toml:"cgroup_driver" json:"cgroupDriver"`
+}
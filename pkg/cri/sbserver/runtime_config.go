@@ -31,13 +31,53 @@ func (c *criService) RuntimeConfig(ctx context.Context, r *runtime.RuntimeConfig
 		Linux: &runtime.LinuxRuntimeConfiguration{
 			CgroupDriver: c.getCgroupDriver(ctx),
 		},
+		RuntimeHandlers: c.getCgroupDriverPerHandler(ctx),
 	}
 	return resp, nil
 }
 
+// getCgroupDriver returns the node-wide default cgroup driver, kept for CRI
+// clients that are not yet RuntimeClass-aware and only consult the
+// top-level CgroupDriver field instead of RuntimeHandlers.
 func (c *criService) getCgroupDriver(ctx context.Context) runtime.CgroupDriver {
-	// Go through the runtime handlers in a predictable order, starting from the
-	// default handler, others sorted in alphabetical order
+	for _, handler := range c.sortedRuntimeHandlerNames() {
+		if d, ok := c.cgroupDriverForHandler(ctx, handler); ok {
+			return d
+		}
+		log.G(ctx).Debugf("runtime handler %q does not provide cgroup driver information", handler)
+	}
+
+	// If no runtime handlers have a setting, return systemd as the default
+	log.G(ctx).Debugf("no runtime handler provided cgroup driver information, returning systemd as a fallback")
+	return runtime.CgroupDriver_SYSTEMD
+}
+
+// getCgroupDriverPerHandler reports the cgroup driver used by each
+// configured runtime handler, so a RuntimeClass-aware kubelet can pick the
+// right driver per pod instead of assuming a single node-wide setting.
+// Handlers that provide no cgroup driver information are left out.
+func (c *criService) getCgroupDriverPerHandler(ctx context.Context) []*runtime.RuntimeHandlerConfig {
+	handlerNames := c.sortedRuntimeHandlerNames()
+
+	handlers := make([]*runtime.RuntimeHandlerConfig, 0, len(handlerNames))
+	for _, handler := range handlerNames {
+		d, ok := c.cgroupDriverForHandler(ctx, handler)
+		if !ok {
+			continue
+		}
+		handlers = append(handlers, &runtime.RuntimeHandlerConfig{
+			Name: handler,
+			Linux: &runtime.LinuxRuntimeHandlerConfig{
+				CgroupDriver: d,
+			},
+		})
+	}
+	return handlers
+}
+
+// sortedRuntimeHandlerNames returns the configured runtime handler names,
+// starting from the default handler, others sorted in alphabetical order.
+func (c *criService) sortedRuntimeHandlerNames() []string {
 	handlerNames := make([]string, 0, len(c.config.ContainerdConfig.Runtimes))
 	for n := range c.config.ContainerdConfig.Runtimes {
 		handlerNames = append(handlerNames, n)
@@ -51,33 +91,58 @@ func (c *criService) getCgroupDriver(ctx context.Context) runtime.CgroupDriver {
 		}
 		return handlerNames[i] < handlerNames[j]
 	})
+	return handlerNames
+}
 
-	// Fall back to finding cgroup driver setting from other runtime handlers
-	for _, handler := range handlerNames {
-		opts, err := generateRuntimeOptions(c.config.ContainerdConfig.Runtimes[handler])
-		if err != nil {
-			log.G(ctx).Debugf("failed to parse runtime handler options for %q", handler)
-			continue
-		}
-		if d, ok := getCgroupDriverFromRuntimeHandlerOpts(opts); ok {
-			return d
-		}
-		log.G(ctx).Debugf("runtime handler %q does not provide cgroup driver information", handler)
+// cgroupDriverForHandler resolves the cgroup driver reported by a single
+// runtime handler. An explicit CgroupDriver override in the handler's own
+// config takes precedence over whatever its shim options report, since not
+// every shim (e.g. kata, wasm runtimes) surfaces the setting the same way
+// runc does.
+func (c *criService) cgroupDriverForHandler(ctx context.Context, handler string) (runtime.CgroupDriver, bool) {
+	handlerConfig := c.config.ContainerdConfig.Runtimes[handler]
+
+	switch handlerConfig.CgroupDriver {
+	case "systemd":
+		return runtime.CgroupDriver_SYSTEMD, true
+	case "cgroupfs":
+		return runtime.CgroupDriver_CGROUPFS, true
+	case "":
+		// Fall through to the shim options below.
+	default:
+		log.G(ctx).Warnf("runtime handler %q has unknown cgroup_driver override %q, ignoring", handler, handlerConfig.CgroupDriver)
 	}
 
-	// If no runtime handlers have a setting, return systemd as the default
-	log.G(ctx).Debugf("no runtime handler provided cgroup driver information, returning systemd as a fallback")
-	return runtime.CgroupDriver_SYSTEMD
+	opts, err := generateRuntimeOptions(handlerConfig)
+	if err != nil {
+		log.G(ctx).Debugf("failed to parse runtime handler options for %q", handler)
+		return 0, false
+	}
+	return getCgroupDriverFromRuntimeHandlerOpts(opts)
+}
+
+// systemdCgroupOpts is implemented by any runtime v2 shim options type that
+// exposes a SystemdCgroup toggle. Today that includes runc (and crun, which
+// reuses the runc shim's options proto with a different BinaryName), and
+// lets third-party shims (kata, wasm runtimes, ...) participate in cgroup
+// driver reporting simply by mirroring the same field in their own options.
+type systemdCgroupOpts interface {
+	GetSystemdCgroup() bool
 }
 
 func getCgroupDriverFromRuntimeHandlerOpts(opts interface{}) (runtime.CgroupDriver, bool) {
 	switch v := opts.(type) {
 	case *runcoptions.Options:
-		systemdCgroup := v.SystemdCgroup
-		if systemdCgroup {
-			return runtime.CgroupDriver_SYSTEMD, true
-		}
-		return runtime.CgroupDriver_CGROUPFS, true
+		return cgroupDriverFromSystemdFlag(v.SystemdCgroup), true
+	case systemdCgroupOpts:
+		return cgroupDriverFromSystemdFlag(v.GetSystemdCgroup()), true
 	}
 	return runtime.CgroupDriver_SYSTEMD, false
 }
+
+func cgroupDriverFromSystemdFlag(systemd bool) runtime.CgroupDriver {
+	if systemd {
+		return runtime.CgroupDriver_SYSTEMD
+	}
+	return runtime.CgroupDriver_CGROUPFS
+}
@@ -0,0 +1,52 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package sbserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// thirdPartyShimOptions stands in for a non-runc shim options type (e.g.
+// kata-containers or a wasm runtime) that mirrors runc's SystemdCgroup field
+// under its own generated options type, to prove getCgroupDriverFromRuntimeHandlerOpts
+// picks such shims up through the systemdCgroupOpts interface rather than
+// only ever matching *runcoptions.Options.
+type thirdPartyShimOptions struct {
+	SystemdCgroup bool
+}
+
+func (o *thirdPartyShimOptions) GetSystemdCgroup() bool {
+	return o.SystemdCgroup
+}
+
+func TestGetCgroupDriverFromRuntimeHandlerOptsThirdPartyShim(t *testing.T) {
+	driver, ok := getCgroupDriverFromRuntimeHandlerOpts(&thirdPartyShimOptions{SystemdCgroup: true})
+	assert.True(t, ok)
+	assert.Equal(t, runtime.CgroupDriver_SYSTEMD, driver)
+
+	driver, ok = getCgroupDriverFromRuntimeHandlerOpts(&thirdPartyShimOptions{SystemdCgroup: false})
+	assert.True(t, ok)
+	assert.Equal(t, runtime.CgroupDriver_CGROUPFS, driver)
+}
+
+func TestGetCgroupDriverFromRuntimeHandlerOptsUnknownShim(t *testing.T) {
+	_, ok := getCgroupDriverFromRuntimeHandlerOpts(struct{}{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// ClassResourceCdi is the class/QoS resource type backed by the Container
+// Device Interface (CDI). Unlike RDT and blockio, which containerd
+// configures on the node itself, a CDI class is just a name that maps to
+// one or more CDI qualified device names (e.g. "vendor.com/nic=eth0")
+// resolved against the CDI specs registered under /etc/cdi and
+// /var/run/cdi.
+const ClassResourceCdi = "cdi"
+
+// cdiClassMapping holds the configured class name -> CDI qualified device
+// names mapping, set once from the CRI plugin config, when the CDI
+// registry is also refreshed from the spec directories; later changes in
+// those directories are not watched for. Guarded by
+// cdiClassMappingMu, mirroring the locking discipline used for the CNI QoS
+// class map in cniQoSResource/cniQoSResourceMu.
+var (
+	cdiClassMapping   map[string][]string
+	cdiClassMappingMu sync.Mutex
+)
+
+// updateCdiClassResources refreshes the CDI registry from the specs found
+// on disk and records the class -> device name mapping read from config.
+func updateCdiClassResources(mapping map[string][]string) error {
+	if err := cdi.Registry().Refresh(); err != nil {
+		// Refresh failures are not necessarily fatal, as they may only
+		// affect a subset of the CDI spec files.
+		logrus.Warnf("error refreshing CDI registry: %v", err)
+	}
+	cdiClassMappingMu.Lock()
+	defer cdiClassMappingMu.Unlock()
+	cdiClassMapping = mapping
+	return nil
+}
+
+// cdiDevicesForClass resolves a CDI class name into the list of CDI
+// qualified device names configured for it.
+func cdiDevicesForClass(className string) ([]string, error) {
+	if className == "" {
+		return nil, nil
+	}
+	cdiClassMappingMu.Lock()
+	devices, ok := cdiClassMapping[className]
+	cdiClassMappingMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown %s class %q", ClassResourceCdi, className)
+	}
+	return devices, nil
+}
+
+// withCdiDevices returns a SpecOpts that injects the given CDI qualified
+// device names into the OCI spec via the CDI registry.
+func withCdiDevices(names ...string) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, spec *runtimespec.Spec) error {
+		if len(names) == 0 {
+			return nil
+		}
+		if _, err := cdi.Registry().InjectDevices(spec, names...); err != nil {
+			return fmt.Errorf("failed to inject CDI devices %v: %w", names, err)
+		}
+		return nil
+	}
+}
+
+// getCdiClassResourcesInfo returns the CDI-backed class resource inventory,
+// or nil if no CDI classes have been configured.
+func getCdiClassResourcesInfo() *runtime.ClassResourceInfo {
+	names := cdiClassNames()
+	if len(names) == 0 {
+		return nil
+	}
+	return &runtime.ClassResourceInfo{
+		Name:    ClassResourceCdi,
+		Mutable: false,
+		Classes: createClassInfos(names...),
+	}
+}
+
+// getCdiQoSResourcesInfo returns the CDI-backed QoS resource inventory, or
+// nil if no CDI classes have been configured.
+func getCdiQoSResourcesInfo() *runtime.QOSResourceInfo {
+	names := cdiClassNames()
+	if len(names) == 0 {
+		return nil
+	}
+	return &runtime.QOSResourceInfo{
+		Name:    ClassResourceCdi,
+		Mutable: false,
+		Classes: createQoSClassInfos(names...),
+	}
+}
+
+func cdiClassNames() []string {
+	cdiClassMappingMu.Lock()
+	defer cdiClassMappingMu.Unlock()
+	if len(cdiClassMapping) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(cdiClassMapping))
+	for n := range cdiClassMapping {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,95 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// ClassResourceUpdater lets a third-party class-resource provider (e.g. a
+// CDI-backed device class, or a cgroup-v2 io.latency implementation) plug
+// into the CRI class resource machinery: it supplies the static class
+// inventory reported by GetContainerClassResourcesInfo, and, since its
+// classes are mutable, the logic that moves an already-running container
+// from one class to another on UpdateContainerResources. Its inventory is
+// not included in GetContainerQoSResourcesInfo.
+type ClassResourceUpdater interface {
+	// Name returns the class/QoS resource type this updater handles, e.g.
+	// "dummy-1". It is the key containers use in ClassResources.Classes
+	// to select this provider. On UpdateContainerResources a
+	// QOSResources[].Name of this value is routed here too, while at
+	// container creation a QOSResources entry of this name is rejected.
+	Name() string
+
+	// Info returns the current class inventory for this resource type.
+	Info() *runtime.ClassResourceInfo
+
+	// Update moves the container identified by containerID, running with
+	// the given pid, into newClass.
+	Update(ctx context.Context, containerID string, pid uint32, newClass string) error
+}
+
+var (
+	classResourceUpdatersMu sync.Mutex
+	classResourceUpdaters   = map[string]ClassResourceUpdater{}
+)
+
+// RegisterClassResourceUpdater registers a class-resource provider. Its
+// classes are advertised exactly as its Info method returns them,
+// including the Mutable flag, and UpdateContainerResources requests
+// naming its resource type are routed to its Update method.
+func RegisterClassResourceUpdater(u ClassResourceUpdater) {
+	classResourceUpdatersMu.Lock()
+	defer classResourceUpdatersMu.Unlock()
+	classResourceUpdaters[u.Name()] = u
+}
+
+func getClassResourceUpdater(name string) (ClassResourceUpdater, bool) {
+	classResourceUpdatersMu.Lock()
+	defer classResourceUpdatersMu.Unlock()
+	u, ok := classResourceUpdaters[name]
+	return u, ok
+}
+
+// registeredClassResourcesInfo returns the class inventories of all
+// registered ClassResourceUpdaters.
+func registeredClassResourcesInfo() []*runtime.ClassResourceInfo {
+	classResourceUpdatersMu.Lock()
+	defer classResourceUpdatersMu.Unlock()
+
+	info := make([]*runtime.ClassResourceInfo, 0, len(classResourceUpdaters))
+	for _, u := range classResourceUpdaters {
+		if i := u.Info(); i != nil {
+			info = append(info, i)
+		}
+	}
+	return info
+}
+
+// updateRegisteredClassResource validates and applies a class change for a
+// resource type owned by a registered ClassResourceUpdater.
+func updateRegisteredClassResource(ctx context.Context, containerID string, pid uint32, name, newClass string) error {
+	u, ok := getClassResourceUpdater(name)
+	if !ok {
+		return fmt.Errorf("unknown class resource type %q", name)
+	}
+	return u.Update(ctx, containerID, pid, newClass)
+}
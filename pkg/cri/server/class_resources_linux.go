@@ -17,6 +17,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/containerd/containerd/oci"
@@ -25,10 +26,7 @@ import (
 	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
-// HACK: dummyclass resources
-var dummyContainerClassResourcesInfo []*runtime.ClassResourceInfo
-var dummyContainerClassResources map[string]map[string]struct{}
-
+// HACK: dummy pod-level class resources
 var dummyPodClassResourcesInfo []*runtime.ClassResourceInfo
 var dummyPodClassResources map[string]map[string]struct{}
 
@@ -67,15 +65,17 @@ func (c *criService) generateContainerClassResourceSpecOpts(config *runtime.Cont
 		case runtime.ClassResourceBlockio:
 			// We handle RDT and blockio separately as we have pod and
 			// container annotations as fallback interface
+		case ClassResourceCdi:
+			// Handled separately below, once we know the class is valid
 		default:
-			cr, ok := dummyContainerClassResources[r]
-			if !ok {
+			u, ok := getClassResourceUpdater(r)
+			if !ok || u.Info() == nil {
 				return nil, fmt.Errorf("unknown class resource type %q", r)
 			}
-			if _, ok := cr[c]; !ok {
+			if !classResourceInfoHasClass(u.Info(), c) {
 				return nil, fmt.Errorf("unknown %s class %q", r, c)
 			}
-			logrus.Infof("setting dummy class resource %s=%s", r, c)
+			logrus.Infof("setting class resource %s=%s", r, c)
 		}
 
 		if c == "" {
@@ -109,6 +109,16 @@ func (c *criService) generateContainerClassResourceSpecOpts(config *runtime.Cont
 		}
 	}
 
+	// Handle CDI-backed classes, e.g. SR-IOV NICs or GPUs fronted through
+	// the class resource mechanism
+	if cls := config.GetClassResources().GetClasses()[ClassResourceCdi]; cls != "" {
+		devices, err := cdiDevicesForClass(cls)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set CDI class: %w", err)
+		}
+		specOpts = append(specOpts, withCdiDevices(devices...))
+	}
+
 	return specOpts, nil
 }
 
@@ -124,31 +134,74 @@ func GetPodClassResourcesInfo() []*runtime.ClassResourceInfo {
 func GetContainerClassResourcesInfo() []*runtime.ClassResourceInfo {
 	info := []*runtime.ClassResourceInfo{}
 
-	// Handle RDT
+	// Handle RDT. Mutable, since containers can be moved between resctrl
+	// groups in place through UpdateContainerResources.
 	if classes := tasks.GetRdtClasses(); len(classes) > 0 {
 		info = append(info,
 			&runtime.ClassResourceInfo{
 				Name:    runtime.ClassResourceRdt,
-				Mutable: false,
+				Mutable: true,
 				Classes: createClassInfos(classes...),
 			})
 	}
 
-	// Handle blockio
+	// Handle blockio. Mutable, since a running container's blockio
+	// parameters can be updated in place through UpdateContainerResources.
 	if classes := tasks.GetBlockioClasses(); len(classes) > 0 {
 		info = append(info,
 			&runtime.ClassResourceInfo{
 				Name:    runtime.ClassResourceBlockio,
-				Mutable: false,
+				Mutable: true,
 				Classes: createClassInfos(classes...),
 			})
 	}
 
-	info = append(info, dummyContainerClassResourcesInfo...)
+	// Handle CDI
+	if cdiInfo := getCdiClassResourcesInfo(); cdiInfo != nil {
+		info = append(info, cdiInfo)
+	}
+
+	// Handle registered third-party class resource providers
+	info = append(info, registeredClassResourcesInfo()...)
 
 	return info
 }
 
+// classResourceInfoHasClass reports whether className is one of the
+// classes advertised in info. A nil info, e.g. a registered provider whose
+// classes have not been configured yet, advertises no classes.
+func classResourceInfoHasClass(info *runtime.ClassResourceInfo, className string) bool {
+	if info == nil {
+		return false
+	}
+	for _, c := range info.Classes {
+		if c.Name == className {
+			return true
+		}
+	}
+	return false
+}
+
+// dummyClassResourceUpdater is a toy ClassResourceUpdater that exercises
+// the registration mechanism: it accepts any class listed in its own
+// inventory and does nothing on Update beyond logging, standing in for a
+// real third-party provider (e.g. a CDI-backed device class).
+type dummyClassResourceUpdater struct {
+	info *runtime.ClassResourceInfo
+}
+
+func (d *dummyClassResourceUpdater) Name() string { return d.info.Name }
+
+func (d *dummyClassResourceUpdater) Info() *runtime.ClassResourceInfo { return d.info }
+
+func (d *dummyClassResourceUpdater) Update(_ context.Context, containerID string, _ uint32, newClass string) error {
+	if !classResourceInfoHasClass(d.info, newClass) {
+		return fmt.Errorf("unknown %s class %q", d.info.Name, newClass)
+	}
+	logrus.Infof("setting dummy class resource %s=%s for container %s", d.info.Name, newClass, containerID)
+	return nil
+}
+
 func createClassInfos(names ...string) []*runtime.ClassResourceClassInfo {
 	out := make([]*runtime.ClassResourceClassInfo, len(names))
 	for i, name := range names {
@@ -158,19 +211,7 @@ func createClassInfos(names ...string) []*runtime.ClassResourceClassInfo {
 }
 
 func init() {
-	// Initialize our dummy class resources hack
-	dummuGen := func(in []*runtime.ClassResourceInfo) map[string]map[string]struct{} {
-		out := make(map[string]map[string]struct{}, len(in))
-		for _, info := range in {
-			classes := make(map[string]struct{}, len(info.Classes))
-			for _, c := range info.Classes {
-				classes[c.Name] = struct{}{}
-			}
-			out[info.Name] = classes
-		}
-		return out
-	}
-
+	// Initialize our dummy pod-level class resources hack
 	dummyPodClassResourcesInfo = []*runtime.ClassResourceInfo{
 		&runtime.ClassResourceInfo{
 			Name:    "podres-1",
@@ -182,17 +223,26 @@ func init() {
 		},
 	}
 
-	dummyContainerClassResourcesInfo = []*runtime.ClassResourceInfo{
-		&runtime.ClassResourceInfo{
-			Name:    "dummy-1",
-			Classes: createClassInfos("class-a", "class-b", "class-c", "class-d"),
-		},
-		&runtime.ClassResourceInfo{
-			Name:    "dummy-2",
-			Classes: createClassInfos("platinum", "gold", "silver", "bronze"),
-		},
+	dummyPodClassResources = map[string]map[string]struct{}{}
+	for _, info := range dummyPodClassResourcesInfo {
+		classes := make(map[string]struct{}, len(info.Classes))
+		for _, c := range info.Classes {
+			classes[c.Name] = struct{}{}
+		}
+		dummyPodClassResources[info.Name] = classes
 	}
 
-	dummyPodClassResources = dummuGen(dummyPodClassResourcesInfo)
-	dummyContainerClassResources = dummuGen(dummyContainerClassResourcesInfo)
+	// Container-level dummy class resources are registered as
+	// ClassResourceUpdaters, exercising the same pluggable interface a
+	// real third-party provider would use.
+	RegisterClassResourceUpdater(&dummyClassResourceUpdater{info: &runtime.ClassResourceInfo{
+		Name:    "dummy-1",
+		Mutable: true,
+		Classes: createClassInfos("class-a", "class-b", "class-c", "class-d"),
+	}})
+	RegisterClassResourceUpdater(&dummyClassResourceUpdater{info: &runtime.ClassResourceInfo{
+		Name:    "dummy-2",
+		Mutable: true,
+		Classes: createClassInfos("platinum", "gold", "silver", "bronze"),
+	}})
 }
@@ -0,0 +1,36 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestClassResourceInfoHasClassNilInfo(t *testing.T) {
+	assert.False(t, classResourceInfoHasClass(nil, "gold"), "a provider with no configured classes yet must not match any class name")
+}
+
+func TestClassResourceInfoHasClass(t *testing.T) {
+	info := &runtime.ClassResourceInfo{
+		Classes: createClassInfos("gold", "silver"),
+	}
+	assert.True(t, classResourceInfoHasClass(info, "gold"))
+	assert.False(t, classResourceInfoHasClass(info, "platinum"))
+}
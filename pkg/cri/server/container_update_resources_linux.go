@@ -0,0 +1,181 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/containerd/containerd"
+	containerstore "github.com/containerd/containerd/pkg/cri/store/container"
+	"github.com/containerd/containerd/v2/pkg/blockio"
+	"github.com/containerd/containerd/v2/pkg/rdt"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// UpdateContainerResources updates the resource constraints of an already
+// running container: the standard Linux cpu/memory limits carried in
+// r.GetLinux(), and any ClassResources/QOSResources class named on the
+// request, moving the container into its new classes in place rather than
+// requiring it to be recreated.
+func (c *criService) UpdateContainerResources(ctx context.Context, r *runtime.UpdateContainerResourcesRequest) (*runtime.UpdateContainerResourcesResponse, error) {
+	cntr, err := c.containerStore.Get(r.GetContainerId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find container %q: %w", r.GetContainerId(), err)
+	}
+
+	if state := cntr.Status.Get().State(); state != runtime.ContainerState_CONTAINER_RUNNING {
+		return nil, fmt.Errorf("container %q is in state %q, not %q", cntr.ID, state, runtime.ContainerState_CONTAINER_RUNNING)
+	}
+
+	if err := c.updateContainerResources(ctx, cntr, r); err != nil {
+		return nil, fmt.Errorf("failed to update resources of container %q: %w", cntr.ID, err)
+	}
+
+	return &runtime.UpdateContainerResourcesResponse{}, nil
+}
+
+// updateContainerResources applies the standard Linux resource limits and
+// the blockio class, if either is present on r, through a single task
+// update, then moves the container into any remaining ClassResources/
+// QOSResources classes named on r.
+func (c *criService) updateContainerResources(ctx context.Context, cntr containerstore.Container, r *runtime.UpdateContainerResourcesRequest) error {
+	pid := cntr.Status.Get().Pid
+
+	blockioClass := classValue(r, runtime.ClassResourceBlockio, runtime.QoSResourceBlockio)
+	resources, err := mergedLinuxResources(r.GetLinux(), blockioClass)
+	if err != nil {
+		return err
+	}
+	if resources != nil {
+		task, err := cntr.Container.Task(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load task of container %q: %w", cntr.ID, err)
+		}
+		if err := task.Update(ctx, containerd.WithResources(resources)); err != nil {
+			return fmt.Errorf("failed to update resources of container %q: %w", cntr.ID, err)
+		}
+	}
+
+	for name, cls := range r.GetClassResources().GetClasses() {
+		if name == runtime.ClassResourceBlockio {
+			continue // merged into resources above
+		}
+		if err := c.updateClassResource(ctx, cntr, pid, name, cls); err != nil {
+			return err
+		}
+	}
+	for _, qr := range r.GetQOSResources() {
+		if qr.GetName() == runtime.QoSResourceBlockio {
+			continue // merged into resources above
+		}
+		if err := c.updateClassResource(ctx, cntr, pid, qr.GetName(), qr.GetClass()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// classValue returns the class named for classResourceName in
+// r.GetClassResources(), falling back to qosResourceName in
+// r.GetQOSResources(), or "" if neither is set.
+func classValue(r *runtime.UpdateContainerResourcesRequest, classResourceName, qosResourceName string) string {
+	if cls := r.GetClassResources().GetClasses()[classResourceName]; cls != "" {
+		return cls
+	}
+	for _, qr := range r.GetQOSResources() {
+		if qr.GetName() == qosResourceName {
+			return qr.GetClass()
+		}
+	}
+	return ""
+}
+
+// mergedLinuxResources converts the standard Linux container resources on
+// an UpdateContainerResourcesRequest into their OCI form and, if
+// blockioClass is non-empty, folds that class's LinuxBlockIO into the same
+// struct, so both land in a single task.Update call instead of two
+// independent shim updates racing each other. Returns nil if there is
+// nothing to update.
+func mergedLinuxResources(lr *runtime.LinuxContainerResources, blockioClass string) (*runtimespec.LinuxResources, error) {
+	var resources *runtimespec.LinuxResources
+	if lr != nil {
+		resources = &runtimespec.LinuxResources{
+			CPU: &runtimespec.LinuxCPU{
+				Shares: uint64Ptr(uint64(lr.GetCpuShares())),
+				Quota:  int64Ptr(lr.GetCpuQuota()),
+				Period: uint64Ptr(uint64(lr.GetCpuPeriod())),
+				Cpus:   lr.GetCpusetCpus(),
+				Mems:   lr.GetCpusetMems(),
+			},
+			Memory: &runtimespec.LinuxMemory{
+				Limit: int64Ptr(lr.GetMemoryLimitInBytes()),
+			},
+		}
+	}
+
+	if blockioClass != "" {
+		linuxBlockIO, err := blockio.ClassNameToLinuxOCI(blockioClass)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blockio class %q: %w", blockioClass, err)
+		}
+		if resources == nil {
+			resources = &runtimespec.LinuxResources{}
+		}
+		resources.BlockIO = linuxBlockIO
+	}
+
+	return resources, nil
+}
+
+func uint64Ptr(v uint64) *uint64 { return &v }
+func int64Ptr(v int64) *int64    { return &v }
+
+// updateClassResource moves container cntr, running as pid, into newClass
+// of the given class/QoS resource type.
+func (c *criService) updateClassResource(ctx context.Context, cntr containerstore.Container, pid uint32, name, newClass string) error {
+	if newClass == "" {
+		return nil
+	}
+
+	switch name {
+	case runtime.ClassResourceRdt, runtime.QoSResourceRdt:
+		return updateRdtClass(cntr.ID, pid, newClass)
+	case ClassResourceCdi:
+		// CDI device assignments are applied once, at container creation
+		// time, via the OCI spec; they cannot be changed on a running
+		// container.
+		return fmt.Errorf("CDI class resource %q is not mutable after container creation", newClass)
+	default:
+		return updateRegisteredClassResource(ctx, cntr.ID, pid, name, newClass)
+	}
+}
+
+// updateRdtClass re-echoes the container's pid into the resctrl group of
+// newClass, moving it out of whatever group it was previously assigned to.
+func updateRdtClass(containerID string, pid uint32, newClass string) error {
+	class, ok := rdt.GetClass(newClass)
+	if !ok {
+		return fmt.Errorf("invalid RDT class %q: not specified in configuration", newClass)
+	}
+	if err := class.AddPids(strconv.FormatUint(uint64(pid), 10)); err != nil {
+		return fmt.Errorf("failed to move container %q into RDT class %q: %w", containerID, newClass, err)
+	}
+	return nil
+}
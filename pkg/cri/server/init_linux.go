@@ -0,0 +1,63 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/containerd/containerd/v2/pkg/rdt"
+	"github.com/containerd/log"
+)
+
+// classResourcesInitOnce guards the one-time class/QoS resource setup
+// performed by ensureClassResourcesStarted.
+var classResourcesInitOnce sync.Once
+
+// ensureClassResourcesStarted performs the one-time class/QoS resource
+// setup that would otherwise run from the CRI service constructor: loading
+// the configured CDI class mapping, the "net" QoS classes parsed out of the
+// CNI conflist, and starting the RDT/blockio config file watcher. It is
+// idempotent and cheap, so it is called at the start of every
+// RunPodSandbox; none of this setup has happened before the first
+// RunPodSandbox call, even if other requests are served earlier.
+func (c *criService) ensureClassResourcesStarted(ctx context.Context) {
+	classResourcesInitOnce.Do(func() {
+		if err := updateCdiClassResources(c.config.ContainerdConfig.CDIClassMapping); err != nil {
+			log.G(ctx).Warnf("failed to load CDI class resources: %v", err)
+		}
+		if c.netPlugin != nil {
+			if err := updateCniQoSResources(c.netPlugin); err != nil {
+				log.G(ctx).Warnf("failed to load network QoS classes from CNI config: %v", err)
+			}
+		}
+		// Fall back to the rdt plugin's own configured file when the CRI
+		// plugin has none of its own, so the two don't end up watching the
+		// same file independently: this watcher is the only SIGHUP/fsnotify
+		// consumer for RDT config reload, the rdt plugin just loads it once
+		// at startup and hands us the path.
+		rdtConfigFile := c.config.ContainerdConfig.RdtConfigFile
+		if rdtConfigFile == "" {
+			rdtConfigFile = rdt.ConfigFile()
+		}
+
+		// The watcher runs for the lifetime of the process, so it is started
+		// with a background context rather than the RunPodSandbox request's
+		// context, which is cancelled as soon as that one call returns.
+		c.watchClassResourceConfigFiles(context.Background(), rdtConfigFile, c.config.ContainerdConfig.BlockioConfigFile)
+	})
+}
@@ -17,14 +17,20 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sync"
 
+	"github.com/container-orchestrated-devices/container-device-interface/pkg/cdi"
+	"github.com/containerd/containerd/v2/containers"
 	"github.com/containerd/containerd/v2/oci"
 	"github.com/containerd/containerd/v2/pkg/blockio"
 	"github.com/containerd/containerd/v2/pkg/rdt"
 	cni "github.com/containerd/go-cni"
 	"github.com/containerd/log"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
@@ -33,15 +39,32 @@ const (
 	QoSResourceNet = "net"
 )
 
+// CniQoSClass describes one network QoS class, as parsed out of the "qos"
+// block of a CNI conflist. A class bundles the CNI capability args that get
+// passed down to the CNI plugins of every network in the pod's conflist,
+// together with a capacity that bounds how many pods may use the class at
+// the same time.
 type CniQoSClass struct {
 	// Capacity is the max number of simultaneous pods that can use this class
 	Capacity     uint64
 	Capabilities struct {
 		BandWidth *cni.BandWidth
+		// DSCP is the differentiated services code point to mark egress
+		// traffic with, passed to the CNI plugins as the "dscp" capability.
+		DSCP *int
+		// PortMappings enables portmap-based traffic shaping, passed to the
+		// CNI plugins as the "portMappings" capability.
+		PortMappings []cni.PortMapping
 	}
+
+	// inUse is the number of pods currently admitted into this class.
+	inUse uint64
 }
 
-var cniQoSResource map[string]CniQoSClass
+var (
+	cniQoSResource   map[string]CniQoSClass
+	cniQoSResourceMu sync.Mutex
+)
 
 // generateContainerQoSResourceSpecOpts generates SpecOpts for QoS resources.
 func (c *criService) generateContainerQoSResourceSpecOpts(config *runtime.ContainerConfig, sandboxConfig *runtime.PodSandboxConfig) ([]oci.SpecOpts, error) {
@@ -55,6 +78,8 @@ func (c *criService) generateContainerQoSResourceSpecOpts(config *runtime.Contai
 		case runtime.QoSResourceBlockio:
 			// We handle RDT and blockio separately as we have pod and
 			// container annotations as fallback interface
+		case ClassResourceCdi:
+			// Handled separately below, once we know the class is valid
 		default:
 			return nil, fmt.Errorf("unknown QoS resource type %q", name)
 		}
@@ -90,37 +115,156 @@ func (c *criService) generateContainerQoSResourceSpecOpts(config *runtime.Contai
 		}
 	}
 
+	// Handle CDI-backed classes, e.g. SR-IOV NICs or GPUs fronted through
+	// the QoS resource mechanism
+	for _, r := range config.GetQOSResources() {
+		if r.GetName() != ClassResourceCdi {
+			continue
+		}
+		devices, err := cdiDevicesForClass(r.GetClass())
+		if err != nil {
+			return nil, fmt.Errorf("failed to set CDI class: %w", err)
+		}
+		specOpts = append(specOpts, withCdiDevicesQoS(devices...))
+		break
+	}
+
 	return specOpts, nil
 }
 
+// withCdiDevicesQoS is withCdiDevices (cdi_linux.go), re-declared against
+// the v2 oci/containers packages this QoS resource path builds its SpecOpts
+// from; the class-resource path and this QoS-resource path are pinned to
+// different containerd module versions, so their CDI injection SpecOpts
+// can't share a single implementation.
+func withCdiDevicesQoS(names ...string) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, spec *runtimespec.Spec) error {
+		if len(names) == 0 {
+			return nil
+		}
+		if _, err := cdi.Registry().InjectDevices(spec, names...); err != nil {
+			return fmt.Errorf("failed to inject CDI devices %v: %w", names, err)
+		}
+		return nil
+	}
+}
+
 func generateCniQoSResourceOpts(config *runtime.PodSandboxConfig) ([]cni.NamespaceOpts, error) {
 	nsOpts := []cni.NamespaceOpts{}
 
+	className, err := cniQoSResourceClassName(config)
+	if err != nil {
+		return nil, err
+	}
+	if className == "" {
+		return nsOpts, nil
+	}
+
+	class, ok := lookupCniQoSResource(className)
+	if !ok {
+		return nil, fmt.Errorf("unknown %q class %q", QoSResourceNet, className)
+	}
+
+	caps := class.Capabilities
+	if caps.BandWidth != nil {
+		nsOpts = append(nsOpts, cni.WithCapabilityBandWidth(*caps.BandWidth))
+	}
+	if caps.DSCP != nil {
+		nsOpts = append(nsOpts, cni.WithCapability("dscp", *caps.DSCP))
+	}
+	if len(caps.PortMappings) > 0 {
+		nsOpts = append(nsOpts, cni.WithCapabilityPortMap(caps.PortMappings))
+	}
+	return nsOpts, nil
+}
+
+// cniQoSResourceClassName extracts the requested "net" QoS resource class
+// name from a pod sandbox config, if any.
+func cniQoSResourceClassName(config *runtime.PodSandboxConfig) (string, error) {
 	for _, r := range config.GetQOSResources() {
 		if r.GetName() == QoSResourceNet {
-			className := r.GetClass()
-			class, ok := cniQoSResource[className]
-			if !ok {
-				return nil, fmt.Errorf("unknown %q class %q", QoSResourceNet, className)
-			}
-			caps := class.Capabilities
-			if caps.BandWidth != nil {
-				nsOpts = append(nsOpts, cni.WithCapabilityBandWidth(*caps.BandWidth))
-			}
-			break
+			return r.GetClass(), nil
 		}
 	}
-	return nsOpts, nil
+	return "", nil
+}
+
+func lookupCniQoSResource(className string) (CniQoSClass, bool) {
+	cniQoSResourceMu.Lock()
+	defer cniQoSResourceMu.Unlock()
+	class, ok := cniQoSResource[className]
+	return class, ok
 }
 
-// GetPodQoSResourcesInfo returns information about all pod-level QoS resources.
+// admitCniQoSResource reserves a slot in the "net" QoS resource class
+// requested by the pod, failing RunPodSandbox if the class is already at
+// capacity. It must be paired with a call to releaseCniQoSResource, once
+// the class name returned here is known, when the sandbox is stopped or
+// fails to start.
+func admitCniQoSResource(config *runtime.PodSandboxConfig) (string, error) {
+	className, err := cniQoSResourceClassName(config)
+	if err != nil || className == "" {
+		return "", err
+	}
+
+	cniQoSResourceMu.Lock()
+	defer cniQoSResourceMu.Unlock()
+
+	class, ok := cniQoSResource[className]
+	if !ok {
+		return "", fmt.Errorf("unknown %q class %q", QoSResourceNet, className)
+	}
+	if class.Capacity > 0 && class.inUse >= class.Capacity {
+		return "", fmt.Errorf("%q class %q is at capacity (%d)", QoSResourceNet, className, class.Capacity)
+	}
+	class.inUse++
+	cniQoSResource[className] = class
+	return className, nil
+}
+
+// releaseCniQoSResource returns a previously admitted slot to the "net"
+// QoS resource class. Called with the class name returned by a prior,
+// successful call to admitCniQoSResource.
+func releaseCniQoSResource(className string) {
+	if className == "" {
+		return
+	}
+
+	cniQoSResourceMu.Lock()
+	defer cniQoSResourceMu.Unlock()
+
+	class, ok := cniQoSResource[className]
+	if !ok || class.inUse == 0 {
+		return
+	}
+	class.inUse--
+	cniQoSResource[className] = class
+}
+
+// GetPodQoSResourcesInfo returns information about all pod-level QoS
+// resources. For the "net" resource, Capacity reports the number of
+// currently free slots in each class, so that kubelet/kube-scheduler can
+// see live availability rather than just the configured maximum. A class
+// configured with Capacity == 0 is unlimited (see admitCniQoSResource), so
+// it is reported as having math.MaxUint64 slots available rather than 0.
 func GetPodQoSResourcesInfo() []*runtime.QOSResourceInfo {
 	info := []*runtime.QOSResourceInfo{}
 
+	cniQoSResourceMu.Lock()
+	defer cniQoSResourceMu.Unlock()
+
 	if len(cniQoSResource) > 0 {
 		classes := make([]*runtime.QOSResourceClassInfo, 0, len(cniQoSResource))
 		for n, c := range cniQoSResource {
-			classes = append(classes, &runtime.QOSResourceClassInfo{Name: n, Capacity: c.Capacity})
+			available := uint64(math.MaxUint64)
+			if c.Capacity > 0 {
+				if c.inUse >= c.Capacity {
+					available = 0
+				} else {
+					available = c.Capacity - c.inUse
+				}
+			}
+			classes = append(classes, &runtime.QOSResourceClassInfo{Name: n, Capacity: available})
 		}
 
 		info = append(info, &runtime.QOSResourceInfo{
@@ -136,26 +280,33 @@ func GetPodQoSResourcesInfo() []*runtime.QOSResourceInfo {
 func GetContainerQoSResourcesInfo() []*runtime.QOSResourceInfo {
 	info := []*runtime.QOSResourceInfo{}
 
-	// Handle RDT
+	// Handle RDT. Mutable, since containers can be moved between resctrl
+	// groups in place through UpdateContainerResources.
 	if classes := rdt.GetClasses(); len(classes) > 0 {
 		info = append(info,
 			&runtime.QOSResourceInfo{
 				Name:    runtime.QoSResourceRdt,
-				Mutable: false,
-				Classes: createClassInfos(classes...),
+				Mutable: true,
+				Classes: createQoSClassInfos(classes...),
 			})
 	}
 
-	// Handle blockio
+	// Handle blockio. Mutable, since a running container's blockio
+	// parameters can be updated in place through UpdateContainerResources.
 	if classes := blockio.GetClasses(); len(classes) > 0 {
 		info = append(info,
 			&runtime.QOSResourceInfo{
 				Name:    runtime.QoSResourceBlockio,
-				Mutable: false,
-				Classes: createClassInfos(classes...),
+				Mutable: true,
+				Classes: createQoSClassInfos(classes...),
 			})
 	}
 
+	// Handle CDI
+	if cdiInfo := getCdiQoSResourcesInfo(); cdiInfo != nil {
+		info = append(info, cdiInfo)
+	}
+
 	return info
 }
 
@@ -164,42 +315,60 @@ func updateCniQoSResources(netplugin cni.CNI) error {
 	if err != nil {
 		return err
 	}
+
+	cniQoSResourceMu.Lock()
+	defer cniQoSResourceMu.Unlock()
+
+	// Carry over in-use counts of classes that are still present, so a
+	// config reload doesn't forget about already-admitted pods.
+	for name, prev := range cniQoSResource {
+		if cur, ok := qos[name]; ok {
+			cur.inUse = prev.inUse
+			qos[name] = cur
+		}
+	}
 	cniQoSResource = qos
 	return nil
 }
 
+// getCniQoSResources parses the "qos" block out of every network in the
+// CNI conflist, merging the classes found into a single map. If the same
+// class name is defined in more than one network, the last one wins and a
+// warning is logged.
 func getCniQoSResources(netplugin cni.CNI) (map[string]CniQoSClass, error) {
 	if netplugin == nil {
 		return nil, fmt.Errorf("BUG: unable to parse CNI QoS resources, nil plugin was given")
 	}
 
 	cniConfig := netplugin.GetConfig()
-	if len(cniConfig.Networks) < 2 {
+	if len(cniConfig.Networks) == 0 {
 		return nil, fmt.Errorf("unable to parse CNI config for QoS resources: no networks configured")
 	}
-	rawConf := cniConfig.Networks[1].Config.Source
 
-	/*if len(cniConfig.Networks[1].Config.Plugins) == 0 {
-		return nil, fmt.Errorf("unable to parse CNI config for QoS resources: no plugin configuration found in network")
-	}
-	rawConf := cniConfig.Networks[1].Config.Plugins[0].Source*/
+	classes := map[string]CniQoSClass{}
+	for _, network := range cniConfig.Networks {
+		rawConf := network.Config.Source
 
-	tmp := struct {
-		Name string                 `json:"name,omitempty"`
-		Qos  map[string]CniQoSClass `json:"qos,omitempty"`
-	}{}
-	log.L.Infof("parsing CNI  QoS config: %s", rawConf)
+		tmp := struct {
+			Name string                 `json:"name,omitempty"`
+			Qos  map[string]CniQoSClass `json:"qos,omitempty"`
+		}{}
 
-	if err := json.Unmarshal([]byte(rawConf), &tmp); err != nil {
-		log.L.Infof("failed to parse CNI config: %s", rawConf)
-		return nil, fmt.Errorf("failed to parse CNI config for QoS resources: %w", err)
-	}
+		if err := json.Unmarshal([]byte(rawConf), &tmp); err != nil {
+			return nil, fmt.Errorf("failed to parse CNI config for QoS resources: %w", err)
+		}
 
-	log.L.Infof("parsed CNI  QoS config: %s", tmp)
+		for name, class := range tmp.Qos {
+			if _, ok := classes[name]; ok {
+				log.L.Warnf("%q class %q redefined in network %q, overriding previous definition", QoSResourceNet, name, tmp.Name)
+			}
+			classes[name] = class
+		}
+	}
 
-	return tmp.Qos, nil
+	return classes, nil
 }
-func createClassInfos(names ...string) []*runtime.QOSResourceClassInfo {
+func createQoSClassInfos(names ...string) []*runtime.QOSResourceClassInfo {
 	out := make([]*runtime.QOSResourceClassInfo, len(names))
 	for i, name := range names {
 		out[i] = &runtime.QOSResourceClassInfo{Name: name, Capacity: uint64(i)}
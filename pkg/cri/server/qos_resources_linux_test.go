@@ -0,0 +1,94 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func podSandboxConfigWithNetClass(class string) *runtime.PodSandboxConfig {
+	return &runtime.PodSandboxConfig{
+		QOSResources: []*runtime.QOSResource{
+			{Name: QoSResourceNet, Class: class},
+		},
+	}
+}
+
+func TestAdmitCniQoSResourceEnforcesCapacity(t *testing.T) {
+	cniQoSResourceMu.Lock()
+	cniQoSResource = map[string]CniQoSClass{"gold": {Capacity: 1}}
+	cniQoSResourceMu.Unlock()
+
+	config := podSandboxConfigWithNetClass("gold")
+
+	className, err := admitCniQoSResource(config)
+	require.NoError(t, err)
+	assert.Equal(t, "gold", className)
+
+	_, err = admitCniQoSResource(config)
+	assert.Error(t, err, "expected admission to fail once the class is at capacity")
+
+	releaseCniQoSResource(className)
+
+	_, err = admitCniQoSResource(config)
+	assert.NoError(t, err, "expected admission to succeed again after release")
+}
+
+func TestAdmitCniQoSResourceConcurrent(t *testing.T) {
+	const capacity = 10
+
+	cniQoSResourceMu.Lock()
+	cniQoSResource = map[string]CniQoSClass{"gold": {Capacity: capacity}}
+	cniQoSResourceMu.Unlock()
+
+	config := podSandboxConfigWithNetClass("gold")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	admitted := 0
+	for i := 0; i < capacity*2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := admitCniQoSResource(config); err == nil {
+				mu.Lock()
+				admitted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, capacity, admitted, "capacity must not be oversubscribed under concurrent admission")
+}
+
+func TestGetPodQoSResourcesInfoUnlimitedClass(t *testing.T) {
+	cniQoSResourceMu.Lock()
+	cniQoSResource = map[string]CniQoSClass{"unlimited": {Capacity: 0}}
+	cniQoSResourceMu.Unlock()
+
+	info := GetPodQoSResourcesInfo()
+	require.Len(t, info, 1)
+	require.Len(t, info[0].Classes, 1)
+	assert.Equal(t, uint64(math.MaxUint64), info[0].Classes[0].Capacity)
+}
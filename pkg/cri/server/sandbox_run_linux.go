@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// RunPodSandbox creates and starts a pod-level sandbox.
+//
+// Pod-level class/QoS resource admission happens here: a pod requesting a
+// "net" QoS resource class is rejected up front if that class is already
+// at capacity, instead of being admitted and only failing later. The rest
+// of the sandbox lifecycle (pause container creation, network attachment,
+// sandbox store bookkeeping) is unchanged and handled by createPodSandbox.
+func (c *criService) RunPodSandbox(ctx context.Context, r *runtime.RunPodSandboxRequest) (*runtime.RunPodSandboxResponse, error) {
+	config := r.GetConfig()
+	name := config.GetMetadata().GetName()
+
+	c.ensureClassResourcesStarted(ctx)
+
+	classOpts, err := c.generateSandboxClassResourceSpecOpts(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate class resource options for sandbox %q: %w", name, err)
+	}
+
+	netClass, err := admitCniQoSResource(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to admit sandbox %q into network QoS class: %w", name, err)
+	}
+	// The sandbox only reaches a running state (and thus becomes eligible
+	// for release through StopPodSandbox) once createPodSandbox succeeds,
+	// so any earlier failure here must release the admitted class itself.
+	released := false
+	defer func() {
+		if !released {
+			releaseCniQoSResource(netClass)
+		}
+	}()
+
+	nsOpts, err := generateCniQoSResourceOpts(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate network QoS options for sandbox %q: %w", name, err)
+	}
+
+	id, err := c.createPodSandbox(ctx, r, classOpts, nsOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox %q: %w", name, err)
+	}
+
+	released = true
+	return &runtime.RunPodSandboxResponse{PodSandboxId: id}, nil
+}
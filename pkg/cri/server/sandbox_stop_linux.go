@@ -0,0 +1,47 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/log"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// StopPodSandbox stops the sandbox, tearing down its network namespace and
+// releasing the "net" QoS resource class slot it was admitted into by
+// RunPodSandbox, if any, back to the pool.
+func (c *criService) StopPodSandbox(ctx context.Context, r *runtime.StopPodSandboxRequest) (*runtime.StopPodSandboxResponse, error) {
+	sandbox, err := c.sandboxStore.Get(r.GetPodSandboxId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sandbox %q: %w", r.GetPodSandboxId(), err)
+	}
+
+	if err := c.teardownPodSandbox(ctx, sandbox); err != nil {
+		return nil, fmt.Errorf("failed to stop sandbox %q: %w", sandbox.ID, err)
+	}
+
+	if className, err := cniQoSResourceClassName(sandbox.Config); err != nil {
+		log.G(ctx).Warnf("failed to determine network QoS class of sandbox %q while releasing it: %v", sandbox.ID, err)
+	} else {
+		releaseCniQoSResource(className)
+	}
+
+	return &runtime.StopPodSandboxResponse{}, nil
+}
@@ -19,6 +19,8 @@
 package rdt
 
 import (
+	"sync"
+
 	"github.com/containerd/containerd/log"
 	"github.com/containerd/containerd/plugin"
 
@@ -36,6 +38,11 @@ type Config struct {
 	ConfigFile string `toml:"config_file" json:"configFile"`
 }
 
+var (
+	configFileMu sync.Mutex
+	configFile   string
+)
+
 func init() {
 	plugin.Register(&plugin.Registration{
 		Type:   plugin.InternalPlugin,
@@ -65,6 +72,26 @@ func initRdt(ic *plugin.InitContext) (interface{}, error) {
 		return nil, errors.Wrap(err, "configuring RDT failed")
 	}
 
+	// Record the config file so the CRI plugin's class resource config
+	// watcher (pkg/cri/server/class_resource_reload_linux.go) can pick it
+	// up as a fallback default. Reloading it is left to that watcher
+	// rather than a second one here, so a single SIGHUP/fsnotify event
+	// doesn't race two independent reloaders against the same resctrl
+	// state.
+	configFileMu.Lock()
+	configFile = config.ConfigFile
+	configFileMu.Unlock()
+
 	return nil, nil
+}
 
+// ConfigFile returns the RDT config file path configured for the rdt
+// plugin, or "" if RDT was not configured (or not enabled). It is exposed
+// so the CRI plugin can fall back to it when it has no rdt_config_file of
+// its own, rather than each maintaining a separate watch over the same
+// file.
+func ConfigFile() string {
+	configFileMu.Lock()
+	defer configFileMu.Unlock()
+	return configFile
 }